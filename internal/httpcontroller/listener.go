@@ -0,0 +1,62 @@
+// listener.go
+package httpcontroller
+
+import (
+	"net"
+	"time"
+)
+
+// DefaultConnDeadline is the default per-connection read/write deadline
+// applied by WrapListenerWithDeadline. Without it, a slow HTTP client
+// streaming /clips or /spectrograms can tie up a server goroutine
+// indefinitely (see golang/go#16100).
+const DefaultConnDeadline = 5 * time.Second
+
+// deadlineListener wraps a net.Listener so every accepted connection gets a
+// rolling read/write deadline.
+type deadlineListener struct {
+	net.Listener
+	timeout time.Duration
+}
+
+// WrapListenerWithDeadline wraps l so each accepted connection's reads and
+// writes time out after timeout if no progress is made. A non-positive
+// timeout falls back to DefaultConnDeadline.
+func WrapListenerWithDeadline(l net.Listener, timeout time.Duration) net.Listener {
+	if timeout <= 0 {
+		timeout = DefaultConnDeadline
+	}
+	return &deadlineListener{Listener: l, timeout: timeout}
+}
+
+// Accept implements net.Listener.
+func (dl *deadlineListener) Accept() (net.Conn, error) {
+	conn, err := dl.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &deadlineConn{Conn: conn, timeout: dl.timeout}, nil
+}
+
+// deadlineConn resets its read and write deadlines on every call, so the
+// connection is only ever idle for at most timeout between reads or writes.
+type deadlineConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+// Read implements net.Conn.
+func (dc *deadlineConn) Read(b []byte) (int, error) {
+	if err := dc.Conn.SetReadDeadline(time.Now().Add(dc.timeout)); err != nil {
+		return 0, err
+	}
+	return dc.Conn.Read(b)
+}
+
+// Write implements net.Conn.
+func (dc *deadlineConn) Write(b []byte) (int, error) {
+	if err := dc.Conn.SetWriteDeadline(time.Now().Add(dc.timeout)); err != nil {
+		return 0, err
+	}
+	return dc.Conn.Write(b)
+}
@@ -0,0 +1,51 @@
+// metrics.go
+package httpcontroller
+
+import (
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+var (
+	queueDepthGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "birdnet_results_queue_depth",
+		Help: "Current number of buffered results in the BirdNET results queue.",
+	})
+	queueCapacityGauge = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "birdnet_results_queue_capacity",
+		Help: "Capacity of the BirdNET results queue.",
+	})
+	queuePolicyGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "birdnet_results_queue_policy",
+		Help: "1 for the currently selected results queue backpressure policy, 0 for the others.",
+	}, []string{"policy"})
+)
+
+func init() {
+	prometheus.MustRegister(queueDepthGauge, queueCapacityGauge, queuePolicyGauge)
+}
+
+// metricsHandler handles GET /metrics, refreshing the queue gauges from
+// myaudio.QueueStatus before delegating to the standard Prometheus handler.
+func (s *Server) metricsHandler(c echo.Context) error {
+	refreshQueueMetrics()
+	promhttp.Handler().ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+func refreshQueueMetrics() {
+	policy, depth, capacity := myaudio.QueueStatus()
+	queueDepthGauge.Set(float64(depth))
+	queueCapacityGauge.Set(float64(capacity))
+
+	for _, p := range []myaudio.QueuePolicy{myaudio.QueuePolicyDropNewest, myaudio.QueuePolicyDropOldest, myaudio.QueuePolicyBlock} {
+		value := 0.0
+		if p == policy {
+			value = 1.0
+		}
+		queuePolicyGauge.WithLabelValues(p.String()).Set(value)
+	}
+}
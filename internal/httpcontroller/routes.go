@@ -77,6 +77,18 @@ func (s *Server) initRoutes() {
 
 	s.Echo.POST("/update-settings", s.updateSettingsHandler)
 
+	// Audio source introspection and hot-reconfiguration API.
+	s.Echo.GET("/api/v1/sources", s.listSourcesHandler)
+	s.Echo.GET("/api/v1/sources/:id", s.getSourceHandler)
+	s.Echo.PUT("/api/v1/sources/:id", s.updateSourceHandler)
+	s.Echo.GET("/api/v1/sources/:id/timing", s.sourceTimingHandler)
+
+	// Prometheus metrics, including results queue depth and backpressure policy.
+	s.Echo.GET("/metrics", s.metricsHandler)
+
+	// Live audio packet/PCM stream for external waveform/spectrogram consumers.
+	s.Echo.GET("/stream/:source", s.streamHandler)
+
 	// Specific handler for settings route
 	//s.Echo.GET("/settings", s.settingsHandler)
 }
@@ -0,0 +1,24 @@
+// server_listener.go
+package httpcontroller
+
+import (
+	"net"
+	"time"
+)
+
+// Start begins listening on addr, wrapping the underlying net.Listener
+// with a per-connection read/write deadline (see WrapListenerWithDeadline)
+// so a slow client streaming /clips or /spectrograms can't tie up a
+// goroutine indefinitely. connDeadline <= 0 uses DefaultConnDeadline.
+//
+// Callers that bring the server up must call Start instead of
+// s.Echo.Start(addr) directly, or the deadline never gets applied and a
+// slow client can tie up a goroutine indefinitely again.
+func (s *Server) Start(addr string, connDeadline time.Duration) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	s.Echo.Listener = WrapListenerWithDeadline(listener, connDeadline)
+	return s.Echo.Start(addr)
+}
@@ -0,0 +1,117 @@
+// sources.go
+package httpcontroller
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+// sourceResponse is the JSON representation of a myaudio.Source returned by
+// the /api/v1/sources endpoints.
+type sourceResponse struct {
+	ID                  string            `json:"id"`
+	Name                string            `json:"name"`
+	Kind                string            `json:"kind"`
+	Format              string            `json:"format"`
+	SampleRate          int               `json:"sample_rate"`
+	Channels            int               `json:"channels"`
+	BitDepth            int               `json:"bit_depth"`
+	BytesProcessed      uint64            `json:"bytes_processed"`
+	ListenerCount       int               `json:"listener_count"`
+	LastDetection       string            `json:"last_detection,omitempty"`
+	LatencyHistogramMs  map[string]uint64 `json:"latency_histogram_ms"`
+	Gain                float64           `json:"gain"`
+	ConfidenceThreshold float64           `json:"confidence_threshold"`
+	SpeciesFilter       []string          `json:"species_filter,omitempty"`
+}
+
+// sourceUpdateRequest is the JSON body accepted by PUT /api/v1/sources/:id.
+// Omitted fields are left unchanged. Gain, ReplayGainDB, and TargetLUFS each
+// select a different normalizer; set at most one per request.
+type sourceUpdateRequest struct {
+	Gain                *float64 `json:"gain"`
+	ReplayGainDB        *float64 `json:"replay_gain_db"`
+	TargetLUFS          *float64 `json:"target_lufs"`
+	BitDepth            *int     `json:"bit_depth"`
+	ConfidenceThreshold *float64 `json:"confidence_threshold"`
+	SpeciesFilter       []string `json:"species_filter"`
+}
+
+// listSourcesHandler handles GET /api/v1/sources, listing every running
+// audio input with its live metrics.
+func (s *Server) listSourcesHandler(c echo.Context) error {
+	sources := myaudio.Sources.All()
+	resp := make([]sourceResponse, 0, len(sources))
+	for _, src := range sources {
+		resp = append(resp, toSourceResponse(src))
+	}
+	return c.JSON(http.StatusOK, resp)
+}
+
+// getSourceHandler handles GET /api/v1/sources/:id.
+func (s *Server) getSourceHandler(c echo.Context) error {
+	src, ok := myaudio.Sources.Get(c.Param("id"))
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "source not found")
+	}
+	return c.JSON(http.StatusOK, toSourceResponse(src))
+}
+
+// updateSourceHandler handles PUT /api/v1/sources/:id, hot-reconfiguring a
+// running source's gain (fixed, ReplayGain, or EBU R128 loudness target),
+// bit depth override, confidence threshold, or species filter without
+// restarting the process.
+func (s *Server) updateSourceHandler(c echo.Context) error {
+	src, ok := myaudio.Sources.Get(c.Param("id"))
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "source not found")
+	}
+
+	var req sourceUpdateRequest
+	if err := c.Bind(&req); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid request body")
+	}
+
+	if req.BitDepth != nil && !myaudio.IsSupportedBitDepth(*req.BitDepth) {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unsupported bit depth: %d", *req.BitDepth))
+	}
+
+	src.Reconfigure(myaudio.SourceConfig{
+		Gain:                req.Gain,
+		ReplayGainDB:        req.ReplayGainDB,
+		TargetLUFS:          req.TargetLUFS,
+		BitDepthOverride:    req.BitDepth,
+		ConfidenceThreshold: req.ConfidenceThreshold,
+		SpeciesFilter:       req.SpeciesFilter,
+	})
+
+	return c.JSON(http.StatusOK, toSourceResponse(src))
+}
+
+func toSourceResponse(src *myaudio.Source) sourceResponse {
+	snap := src.Snapshot()
+	resp := sourceResponse{
+		ID:                  src.ID,
+		Name:                src.Name,
+		Kind:                src.Kind,
+		Format:              snap.Format,
+		SampleRate:          snap.SampleRate,
+		Channels:            snap.Channels,
+		BitDepth:            snap.BitDepth,
+		BytesProcessed:      snap.BytesProcessed,
+		ListenerCount:       snap.ListenerCount,
+		LatencyHistogramMs:  snap.LatencyHistogramMs,
+		Gain:                snap.Gain,
+		ConfidenceThreshold: snap.ConfidenceThreshold,
+		SpeciesFilter:       snap.SpeciesFilter,
+	}
+	if !snap.LastDetection.IsZero() {
+		resp.LastDetection = snap.LastDetection.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -0,0 +1,158 @@
+// stream.go
+package httpcontroller
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+// packetFrame is the JSON payload of one length-prefixed packet emitted by
+// the packet-stream mode of streamHandler.
+type packetFrame struct {
+	Seq        uint64 `json:"seq"`
+	PtsNs      int64  `json:"pts_ns"`
+	SampleRate int    `json:"sample_rate"`
+	Channels   int    `json:"channels"`
+	PCMBase64  string `json:"pcm_base64"`
+}
+
+// streamHandler handles GET /stream/:source, tapping the same live frames
+// ProcessData consumes for the named source. With an
+// "x-audio-packet-stream: 1" header (or ?format=packets), it emits
+// length-prefixed JSON packets suitable for browser waveform/spectrogram
+// UIs; otherwise it serves a plain PCM/WAV mux of the live source.
+func (s *Server) streamHandler(c echo.Context) error {
+	source := c.Param("source")
+	src, ok := myaudio.Sources.Get(source)
+	if !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "source not found")
+	}
+
+	frames := myaudio.Frames.Subscribe(source)
+	src.AddListener(1)
+	defer func() {
+		src.AddListener(-1)
+		myaudio.Frames.Unsubscribe(source, frames)
+	}()
+
+	if c.Request().Header.Get("x-audio-packet-stream") == "1" || c.QueryParam("format") == "packets" {
+		return s.streamPackets(c, frames)
+	}
+
+	// streamWAV's header always declares 16-bit little-endian PCM with no
+	// byte-order field; frame.PCM is actually the source's raw, pre-decode
+	// bytes (FLAC, G.711, 8/24/32-bit or float32 PCM, or big-endian 16-bit,
+	// all pass through unchanged), so serving it for anything but
+	// FormatPCM16LE would mint a WAV file whose header lies about its
+	// payload -- FormatPCM16BE would play back byte-swapped.
+	snap := src.Snapshot()
+	if snap.Format != myaudio.FormatPCM16LE {
+		return echo.NewHTTPError(http.StatusConflict, "source is not 16-bit little-endian PCM; use ?format=packets instead")
+	}
+	return s.streamWAV(c, frames)
+}
+
+// streamPackets emits one length-prefixed JSON packetFrame per frame.
+func (s *Server) streamPackets(c echo.Context, frames <-chan myaudio.Frame) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "application/octet-stream")
+	res.WriteHeader(http.StatusOK)
+
+	for {
+		select {
+		case frame, ok := <-frames:
+			if !ok {
+				return nil
+			}
+			payload, err := json.Marshal(packetFrame{
+				Seq:        frame.Seq,
+				PtsNs:      time.Now().UnixNano(),
+				SampleRate: frame.SampleRate,
+				Channels:   frame.Channels,
+				PCMBase64:  base64.StdEncoding.EncodeToString(frame.PCM),
+			})
+			if err != nil {
+				return err
+			}
+
+			var lengthPrefix [4]byte
+			binary.BigEndian.PutUint32(lengthPrefix[:], uint32(len(payload)))
+			if _, err := res.Write(lengthPrefix[:]); err != nil {
+				return err
+			}
+			if _, err := res.Write(payload); err != nil {
+				return err
+			}
+			res.Flush()
+		case <-c.Request().Context().Done():
+			return nil
+		}
+	}
+}
+
+// streamWAV serves a minimal streaming WAV mux: a canonical 44-byte header
+// with placeholder RIFF/data sizes (the stream has no fixed length),
+// followed by each frame's raw PCM as it arrives. Only called for sources
+// streamHandler has already confirmed are 16-bit PCM; anything else is
+// rejected in favor of the packet-stream mode above, which sends the raw
+// bytes base64-encoded with the sample rate and channel count but no
+// sample format field.
+func (s *Server) streamWAV(c echo.Context, frames <-chan myaudio.Frame) error {
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "audio/wav")
+	res.WriteHeader(http.StatusOK)
+
+	wroteHeader := false
+	for {
+		select {
+		case frame, ok := <-frames:
+			if !ok {
+				return nil
+			}
+			if !wroteHeader {
+				if _, err := res.Write(wavStreamHeader(frame.SampleRate, frame.Channels)); err != nil {
+					return err
+				}
+				wroteHeader = true
+			}
+			if _, err := res.Write(frame.PCM); err != nil {
+				return err
+			}
+			res.Flush()
+		case <-c.Request().Context().Done():
+			return nil
+		}
+	}
+}
+
+// wavStreamHeader builds a 44-byte canonical WAV header for 16-bit PCM,
+// with RIFF/data chunk sizes set to the streaming convention 0xFFFFFFFF
+// since the total length isn't known up front.
+func wavStreamHeader(sampleRate, channels int) []byte {
+	const bitsPerSample = 16
+	blockAlign := channels * bitsPerSample / 8
+	byteRate := sampleRate * blockAlign
+
+	header := make([]byte, 44)
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], 0xFFFFFFFF)
+	copy(header[8:12], "WAVE")
+	copy(header[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:20], 16)
+	binary.LittleEndian.PutUint16(header[20:22], 1) // PCM
+	binary.LittleEndian.PutUint16(header[22:24], uint16(channels))
+	binary.LittleEndian.PutUint32(header[24:28], uint32(sampleRate))
+	binary.LittleEndian.PutUint32(header[28:32], uint32(byteRate))
+	binary.LittleEndian.PutUint16(header[32:34], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(header[34:36], bitsPerSample)
+	copy(header[36:40], "data")
+	binary.LittleEndian.PutUint32(header[40:44], 0xFFFFFFFF)
+	return header
+}
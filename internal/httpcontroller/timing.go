@@ -0,0 +1,91 @@
+// timing.go
+package httpcontroller
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/tphakala/birdnet-go/internal/myaudio"
+)
+
+// timingHub fans out myaudio.FrameTiming events to any number of SSE
+// subscribers, keyed by source ID.
+type timingHub struct {
+	mu          sync.Mutex
+	subscribers map[string]map[chan myaudio.FrameTiming]struct{}
+}
+
+// Timing is the process-wide timing hub. Timing.Publish has the
+// myaudio.TimingSink signature; /api/v1/sources/:id/timing streams events
+// only for sources whose HTTPSource.Run (or StartHTTPSources) was given
+// Timing.Publish as its onTiming sink, and nothing in this package does so.
+var Timing = &timingHub{subscribers: map[string]map[chan myaudio.FrameTiming]struct{}{}}
+
+// Publish broadcasts a timing event to every current subscriber of source.
+func (h *timingHub) Publish(source string, t myaudio.FrameTiming) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers[source] {
+		select {
+		case ch <- t:
+		default:
+			// Slow subscriber; drop the event rather than block capture.
+		}
+	}
+}
+
+func (h *timingHub) subscribe(source string) chan myaudio.FrameTiming {
+	ch := make(chan myaudio.FrameTiming, 16)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscribers[source] == nil {
+		h.subscribers[source] = map[chan myaudio.FrameTiming]struct{}{}
+	}
+	h.subscribers[source][ch] = struct{}{}
+	return ch
+}
+
+func (h *timingHub) unsubscribe(source string, ch chan myaudio.FrameTiming) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers[source], ch)
+	close(ch)
+}
+
+// sourceTimingHandler handles GET /api/v1/sources/:id/timing, streaming
+// Server-Sent Events with each chunk's wall-clock start, monotonic stream
+// offset, and frame count as it's read, so external tools can align
+// detections with the original stream position.
+func (s *Server) sourceTimingHandler(c echo.Context) error {
+	id := c.Param("id")
+	if _, ok := myaudio.Sources.Get(id); !ok {
+		return echo.NewHTTPError(http.StatusNotFound, "source not found")
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set("Cache-Control", "no-cache")
+	res.Header().Set("Connection", "keep-alive")
+	res.WriteHeader(http.StatusOK)
+
+	ch := Timing.subscribe(id)
+	defer Timing.unsubscribe(id, ch)
+
+	for {
+		select {
+		case t, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			fmt.Fprintf(res, "data: {\"wall_clock\":%q,\"offset_ns\":%d,\"frame_count\":%d}\n\n",
+				t.WallClock.Format(time.RFC3339Nano), t.MonotonicNanos, t.FrameCount)
+			res.Flush()
+		case <-c.Request().Context().Done():
+			return nil
+		}
+	}
+}
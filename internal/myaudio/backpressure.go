@@ -0,0 +1,107 @@
+// backpressure.go
+package myaudio
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/analysis/queue"
+)
+
+// QueuePolicy controls what ProcessData does when queue.ResultsQueue is
+// full, replacing the old unconditional drop-on-full behavior.
+type QueuePolicy int
+
+const (
+	// QueuePolicyDropNewest discards the result currently being enqueued,
+	// leaving the queue's existing contents untouched. This matches the
+	// original "Queue is full!" behavior and is the default.
+	QueuePolicyDropNewest QueuePolicy = iota
+	// QueuePolicyDropOldest discards the oldest queued result to make room
+	// for the new one, turning ResultsQueue into a ring buffer.
+	QueuePolicyDropOldest
+	// QueuePolicyBlock waits up to the configured block timeout for room
+	// in the queue before giving up and dropping the newest result.
+	QueuePolicyBlock
+)
+
+// String returns the policy's metrics/API label.
+func (p QueuePolicy) String() string {
+	switch p {
+	case QueuePolicyDropOldest:
+		return "drop-oldest"
+	case QueuePolicyBlock:
+		return "block"
+	default:
+		return "drop-newest"
+	}
+}
+
+var (
+	queuePolicyMu     sync.RWMutex
+	activeQueuePolicy = QueuePolicyDropNewest
+	queueBlockTimeout = 5 * time.Second
+)
+
+// SetQueuePolicy selects the backpressure policy ProcessData uses when
+// queue.ResultsQueue is full.
+func SetQueuePolicy(policy QueuePolicy) {
+	queuePolicyMu.Lock()
+	defer queuePolicyMu.Unlock()
+	activeQueuePolicy = policy
+}
+
+// SetQueueBlockTimeout sets how long QueuePolicyBlock waits for room before
+// giving up and dropping the result.
+func SetQueueBlockTimeout(d time.Duration) {
+	queuePolicyMu.Lock()
+	defer queuePolicyMu.Unlock()
+	queueBlockTimeout = d
+}
+
+// QueueStatus returns the currently selected backpressure policy along with
+// the queue's current depth and capacity, for the /metrics endpoint.
+func QueueStatus() (policy QueuePolicy, depth, capacity int) {
+	queuePolicyMu.RLock()
+	defer queuePolicyMu.RUnlock()
+	return activeQueuePolicy, len(queue.ResultsQueue), cap(queue.ResultsQueue)
+}
+
+// enqueueResult sends msg to queue.ResultsQueue according to the active
+// QueuePolicy.
+func enqueueResult(msg *queue.Results) {
+	queuePolicyMu.RLock()
+	policy := activeQueuePolicy
+	timeout := queueBlockTimeout
+	queuePolicyMu.RUnlock()
+
+	switch policy {
+	case QueuePolicyDropOldest:
+		for {
+			select {
+			case queue.ResultsQueue <- msg:
+				return
+			default:
+				select {
+				case <-queue.ResultsQueue:
+					// Made room; loop around to retry the send.
+				default:
+					// A consumer won the race and drained it already.
+				}
+			}
+		}
+	case QueuePolicyBlock:
+		select {
+		case queue.ResultsQueue <- msg:
+		case <-time.After(timeout):
+			log.Printf("myaudio: queue still full after %v, dropping result", timeout)
+		}
+	default: // QueuePolicyDropNewest
+		select {
+		case queue.ResultsQueue <- msg:
+		default:
+			log.Println("myaudio: queue is full, dropping newest result")
+		}
+	}
+}
@@ -0,0 +1,87 @@
+// backpressure_test.go
+package myaudio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/analysis/queue"
+)
+
+// withTestQueue swaps queue.ResultsQueue for a freshly made channel of the
+// given capacity for the duration of the test, restoring the original on
+// cleanup so other tests aren't affected by a shrunk queue.
+func withTestQueue(t *testing.T, capacity int) {
+	t.Helper()
+	orig := queue.ResultsQueue
+	queue.ResultsQueue = make(chan *queue.Results, capacity)
+	t.Cleanup(func() {
+		queue.ResultsQueue = orig
+		SetQueuePolicy(QueuePolicyDropNewest)
+		SetQueueBlockTimeout(5 * time.Second)
+	})
+}
+
+func TestEnqueueResultDropNewestKeepsQueuedResult(t *testing.T) {
+	withTestQueue(t, 1)
+	SetQueuePolicy(QueuePolicyDropNewest)
+
+	queue.ResultsQueue <- &queue.Results{Source: "first"}
+	enqueueResult(&queue.Results{Source: "second"})
+
+	got := <-queue.ResultsQueue
+	if got.Source != "first" {
+		t.Fatalf("drop-newest: got %q queued, want the original %q to survive", got.Source, "first")
+	}
+}
+
+func TestEnqueueResultDropOldestReplacesQueuedResult(t *testing.T) {
+	withTestQueue(t, 1)
+	SetQueuePolicy(QueuePolicyDropOldest)
+
+	queue.ResultsQueue <- &queue.Results{Source: "first"}
+	enqueueResult(&queue.Results{Source: "second"})
+
+	got := <-queue.ResultsQueue
+	if got.Source != "second" {
+		t.Fatalf("drop-oldest: got %q queued, want the newest %q to have replaced it", got.Source, "second")
+	}
+}
+
+func TestEnqueueResultBlockWaitsThenDropsOnTimeout(t *testing.T) {
+	withTestQueue(t, 1)
+	SetQueuePolicy(QueuePolicyBlock)
+	SetQueueBlockTimeout(20 * time.Millisecond)
+
+	queue.ResultsQueue <- &queue.Results{Source: "first"}
+
+	start := time.Now()
+	enqueueResult(&queue.Results{Source: "second"})
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Fatalf("expected enqueueResult to wait out the block timeout, returned after %v", elapsed)
+	}
+
+	got := <-queue.ResultsQueue
+	if got.Source != "first" {
+		t.Fatalf("block policy: got %q queued, want the original %q left in place after the timeout", got.Source, "first")
+	}
+}
+
+func TestEnqueueResultBlockSucceedsWhenRoomFreesUp(t *testing.T) {
+	withTestQueue(t, 1)
+	SetQueuePolicy(QueuePolicyBlock)
+	SetQueueBlockTimeout(time.Second)
+
+	queue.ResultsQueue <- &queue.Results{Source: "first"}
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		<-queue.ResultsQueue
+	}()
+
+	enqueueResult(&queue.Results{Source: "second"})
+
+	got := <-queue.ResultsQueue
+	if got.Source != "second" {
+		t.Fatalf("block policy: got %q queued, want %q to have been enqueued once room freed up", got.Source, "second")
+	}
+}
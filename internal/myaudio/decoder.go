@@ -0,0 +1,457 @@
+// decoder.go
+package myaudio
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sync"
+
+	"github.com/mewkiz/flac"
+)
+
+// Decoder turns a raw chunk of audio bytes, as read from a source (sound
+// card, RTSP feed, HTTP stream, ...), into per-channel float32 samples in
+// the [-1.0, 1.0] range expected by BirdNET.
+type Decoder interface {
+	// Decode converts raw bytes belonging to a single source into one
+	// float32 slice per channel.
+	Decode(raw []byte) ([][]float32, error)
+	// SampleRate returns the sample rate the decoder was configured with.
+	SampleRate() int
+	// Channels returns the channel count the decoder was configured with.
+	Channels() int
+}
+
+// decoderCloser is implemented by decoders that hold background resources
+// needing explicit cleanup when a caller discards a Decoder without reading
+// it to a clean end of stream -- flacDecoder's parser goroutine, which
+// assumes one continuous bitstream across Decode calls, is the motivating
+// case: a source that reconnects must close the old decoder before its
+// fresh bitstream is ever handed to a new one.
+type decoderCloser interface {
+	Close()
+}
+
+// closeDecoder releases a decoder's background resources, if it holds any.
+// Decoders with no such resources (the common case) are left untouched.
+func closeDecoder(d Decoder) {
+	if c, ok := d.(decoderCloser); ok {
+		c.Close()
+	}
+}
+
+// DecoderFactory builds a Decoder for a given sample rate and channel count.
+// Factories are registered once per format name and instantiated per source
+// so that multiple sources can decode concurrently without sharing state.
+type DecoderFactory func(sampleRate, channels int) Decoder
+
+var (
+	decoderRegistryMu sync.RWMutex
+	decoderRegistry   = map[string]DecoderFactory{}
+)
+
+// RegisterDecoder makes a decoder factory available under the given format
+// name. It is intended to be called from package init functions; a format
+// registered twice overwrites the previous factory.
+func RegisterDecoder(format string, factory DecoderFactory) {
+	decoderRegistryMu.Lock()
+	defer decoderRegistryMu.Unlock()
+	decoderRegistry[format] = factory
+}
+
+// NewDecoder looks up the factory registered for format and instantiates a
+// Decoder for the given sample rate and channel count.
+func NewDecoder(format string, sampleRate, channels int) (Decoder, error) {
+	decoderRegistryMu.RLock()
+	factory, ok := decoderRegistry[format]
+	decoderRegistryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("myaudio: no decoder registered for format %q", format)
+	}
+	return factory(sampleRate, channels), nil
+}
+
+// Well-known decoder format names, used both for registry lookups and for
+// per-source configuration (see Source.Format).
+//
+// FormatPCM8 is unsigned with a 128 bias, the layout WAV/AU files and sound
+// card capture actually use for 8-bit samples. FormatPCM8Signed is the
+// two's-complement variant some raw/IP-camera feeds use instead; it is a
+// distinct, explicitly-named format rather than a default so callers can't
+// silently decode the wrong bias.
+const (
+	FormatPCM8       = "pcm8"
+	FormatPCM8Signed = "pcm8signed"
+	FormatPCM16LE    = "pcm16le"
+	FormatPCM16BE    = "pcm16be"
+	FormatPCM24LE    = "pcm24le"
+	FormatPCM24BE    = "pcm24be"
+	FormatPCM32LE    = "pcm32le"
+	FormatPCM32BE    = "pcm32be"
+	FormatFloat32LE  = "float32le"
+	FormatALaw       = "alaw"
+	FormatULaw       = "ulaw"
+	FormatFLAC       = "flac"
+)
+
+func init() {
+	RegisterDecoder(FormatPCM8, func(sampleRate, channels int) Decoder {
+		return &unsigned8Decoder{sampleRate: sampleRate, channels: channels}
+	})
+	RegisterDecoder(FormatPCM8Signed, newPCMDecoder(8, false, false))
+	RegisterDecoder(FormatPCM16LE, newPCMDecoder(16, false, false))
+	RegisterDecoder(FormatPCM16BE, newPCMDecoder(16, true, false))
+	RegisterDecoder(FormatPCM24LE, newPCMDecoder(24, false, false))
+	RegisterDecoder(FormatPCM24BE, newPCMDecoder(24, true, false))
+	RegisterDecoder(FormatPCM32LE, newPCMDecoder(32, false, false))
+	RegisterDecoder(FormatPCM32BE, newPCMDecoder(32, true, false))
+	RegisterDecoder(FormatFloat32LE, newPCMDecoder(32, false, true))
+	RegisterDecoder(FormatALaw, func(sampleRate, channels int) Decoder {
+		return &g711Decoder{sampleRate: sampleRate, channels: channels, expand: alawToLinear}
+	})
+	RegisterDecoder(FormatULaw, func(sampleRate, channels int) Decoder {
+		return &g711Decoder{sampleRate: sampleRate, channels: channels, expand: ulawToLinear}
+	})
+	RegisterDecoder(FormatFLAC, func(sampleRate, channels int) Decoder {
+		return &flacDecoder{sampleRate: sampleRate, channels: channels}
+	})
+}
+
+// pcmDecoder decodes interleaved fixed-width PCM, signed integer or IEEE
+// float32, in either byte order. It replaces the old hardcoded
+// convert{16,24,32}BitToFloat32 switch in ConvertToFloat32.
+type pcmDecoder struct {
+	bitDepth   int
+	bigEndian  bool
+	float      bool
+	sampleRate int
+	channels   int
+}
+
+func newPCMDecoder(bitDepth int, bigEndian, float bool) DecoderFactory {
+	return func(sampleRate, channels int) Decoder {
+		return &pcmDecoder{bitDepth: bitDepth, bigEndian: bigEndian, float: float, sampleRate: sampleRate, channels: channels}
+	}
+}
+
+func (d *pcmDecoder) SampleRate() int { return d.sampleRate }
+func (d *pcmDecoder) Channels() int   { return d.channels }
+
+func (d *pcmDecoder) Decode(raw []byte) ([][]float32, error) {
+	channels := d.channels
+	if channels < 1 {
+		channels = 1
+	}
+	bytesPerSample := d.bitDepth / 8
+	frameSize := bytesPerSample * channels
+	if frameSize == 0 || len(raw)%frameSize != 0 {
+		return nil, fmt.Errorf("myaudio: %d-bit PCM data length %d is not a multiple of frame size %d", d.bitDepth, len(raw), frameSize)
+	}
+
+	frames := len(raw) / frameSize
+	out := make([][]float32, channels)
+	for c := range out {
+		out[c] = make([]float32, frames)
+	}
+
+	for i := 0; i < frames; i++ {
+		for c := 0; c < channels; c++ {
+			offset := i*frameSize + c*bytesPerSample
+			sample := raw[offset : offset+bytesPerSample]
+			out[c][i] = d.decodeSample(sample)
+		}
+	}
+	return out, nil
+}
+
+func (d *pcmDecoder) decodeSample(sample []byte) float32 {
+	if d.float {
+		bits := d.readUint(sample)
+		return math.Float32frombits(uint32(bits))
+	}
+
+	raw := int64(d.readUint(sample))
+	signBit := int64(1) << (d.bitDepth - 1)
+	if raw&signBit != 0 {
+		raw -= signBit << 1
+	}
+	divisor := float32(signBit)
+	return float32(raw) / divisor
+}
+
+func (d *pcmDecoder) readUint(sample []byte) uint64 {
+	var v uint64
+	if d.bigEndian {
+		for _, b := range sample {
+			v = v<<8 | uint64(b)
+		}
+		return v
+	}
+	for i := len(sample) - 1; i >= 0; i-- {
+		v = v<<8 | uint64(sample[i])
+	}
+	return v
+}
+
+// unsigned8Decoder decodes unsigned 8-bit PCM with a 128 bias (silence is
+// byte 128), the layout used by WAV/AU files and typical sound-card
+// capture. Do not confuse with the signed two's-complement variant
+// registered under FormatPCM8Signed.
+type unsigned8Decoder struct {
+	sampleRate int
+	channels   int
+}
+
+func (d *unsigned8Decoder) SampleRate() int { return d.sampleRate }
+func (d *unsigned8Decoder) Channels() int   { return d.channels }
+
+func (d *unsigned8Decoder) Decode(raw []byte) ([][]float32, error) {
+	channels := d.channels
+	if channels < 1 {
+		channels = 1
+	}
+	if len(raw)%channels != 0 {
+		return nil, fmt.Errorf("myaudio: unsigned 8-bit PCM data length %d is not a multiple of channel count %d", len(raw), channels)
+	}
+
+	frames := len(raw) / channels
+	out := make([][]float32, channels)
+	for c := range out {
+		out[c] = make([]float32, frames)
+	}
+	for i := 0; i < frames; i++ {
+		for c := 0; c < channels; c++ {
+			out[c][i] = (float32(raw[i*channels+c]) - 128) / 128.0
+		}
+	}
+	return out, nil
+}
+
+// g711Decoder decodes G.711 A-law or mu-law, one byte per sample, commonly
+// emitted by cheap IP cameras and SIP/VoIP audio feeds.
+type g711Decoder struct {
+	sampleRate int
+	channels   int
+	expand     func(byte) float32
+}
+
+func (d *g711Decoder) SampleRate() int { return d.sampleRate }
+func (d *g711Decoder) Channels() int   { return d.channels }
+
+func (d *g711Decoder) Decode(raw []byte) ([][]float32, error) {
+	channels := d.channels
+	if channels < 1 {
+		channels = 1
+	}
+	if len(raw)%channels != 0 {
+		return nil, fmt.Errorf("myaudio: G.711 data length %d is not a multiple of channel count %d", len(raw), channels)
+	}
+
+	frames := len(raw) / channels
+	out := make([][]float32, channels)
+	for c := range out {
+		out[c] = make([]float32, frames)
+	}
+	for i := 0; i < frames; i++ {
+		for c := 0; c < channels; c++ {
+			out[c][i] = d.expand(raw[i*channels+c])
+		}
+	}
+	return out, nil
+}
+
+// flacDecoder streams a continuous FLAC bitstream through go-flac for
+// compressed capture sources that don't deliver raw PCM. Chunks handed to
+// Decode are successive slices of one stream, not standalone files, so the
+// decoder parses the header once and keeps the bitstream reader (and its
+// background goroutine) alive across calls rather than re-parsing each
+// chunk from scratch.
+type flacDecoder struct {
+	sampleRate int
+	channels   int
+
+	mu      sync.Mutex
+	chunks  chan []byte
+	frameCh chan [][]float32
+	doneCh  chan error
+	idleCh  chan struct{}
+}
+
+// chunkReader feeds flac.Stream from the byte slices Decode hands it one at
+// a time via chunks, buffering whatever of the current chunk Read hasn't
+// consumed yet. It only ever blocks on chunks (signaling idleCh first) once
+// that buffer is empty. The signal is skipped before the first chunk has
+// arrived, so no stale token is left in idleCh for the first Decode call to
+// mistake for "caught up". After that, an empty buffer is the one point
+// that reliably means "every
+// frame derivable from bytes delivered so far has already been parsed and
+// sent": the parser only calls Read again, needing fresh bytes, after it has
+// fully consumed what buf already held.
+//
+// This replaces an earlier version that signaled idleCh from inside every
+// Read call, including ones serving bytes already in flight from a Write
+// still in progress — which could fire before the corresponding frame was
+// parsed and raced against it in Decode's select.
+type chunkReader struct {
+	chunks <-chan []byte
+	idleCh chan<- struct{}
+	buf    []byte
+	primed bool // set once the first chunk has been received
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.primed {
+			select {
+			case r.idleCh <- struct{}{}:
+			default:
+			}
+		}
+		chunk, ok := <-r.chunks
+		if !ok {
+			return 0, io.EOF
+		}
+		r.buf = chunk
+		r.primed = true
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+func (d *flacDecoder) SampleRate() int { return d.sampleRate }
+func (d *flacDecoder) Channels() int   { return d.channels }
+
+// Close stops the background parser goroutine, if one was ever started, by
+// closing chunks so chunkReader's blocked Read returns io.EOF instead of
+// waiting forever for a chunk that will never come. Safe to call on a
+// flacDecoder that never had Decode called on it.
+func (d *flacDecoder) Close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.chunks != nil {
+		close(d.chunks)
+		d.chunks = nil
+	}
+}
+
+// start launches the background goroutine that owns the flac.Stream and
+// feeds it from chunks Decode sends, so STREAMINFO and bitstream state
+// persist across Decode calls instead of being rebuilt every time.
+func (d *flacDecoder) start() {
+	d.chunks = make(chan []byte)
+	d.frameCh = make(chan [][]float32, 4)
+	d.doneCh = make(chan error, 1)
+	d.idleCh = make(chan struct{}, 1)
+
+	go func() {
+		defer close(d.frameCh)
+
+		stream, err := flac.Parse(&chunkReader{chunks: d.chunks, idleCh: d.idleCh})
+		if err != nil {
+			d.doneCh <- fmt.Errorf("myaudio: parsing FLAC stream: %w", err)
+			return
+		}
+		defer stream.Close()
+
+		channels := int(stream.Info.NChannels)
+		if channels < 1 {
+			channels = 1
+		}
+		divisor := float32(int64(1) << (stream.Info.BitsPerSample - 1))
+
+		for {
+			frame, err := stream.ParseNext()
+			if err != nil {
+				d.doneCh <- nil // clean end of stream (or pipe closed); nothing left to decode
+				return
+			}
+
+			decoded := make([][]float32, channels)
+			for c := 0; c < channels && c < len(frame.Subframes); c++ {
+				sub := frame.Subframes[c]
+				samples := make([]float32, len(sub.Samples))
+				for i, s := range sub.Samples {
+					samples[i] = float32(s) / divisor
+				}
+				decoded[c] = samples
+			}
+			d.frameCh <- decoded
+		}
+	}()
+}
+
+func (d *flacDecoder) Decode(raw []byte) ([][]float32, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.chunks == nil {
+		d.start()
+	}
+
+	if len(raw) > 0 {
+		d.chunks <- raw
+	}
+
+	// Drain every frame the background goroutine has already parsed, then
+	// wait for it to tell us (via idleCh) that it has caught up on the bytes
+	// just sent and is blocked asking for more, rather than racing ahead of
+	// it with a non-blocking check. idleCh only fires once chunkReader's own
+	// buffer is empty, which — because the parser goroutine only calls Read
+	// again after exhausting everything already parseable — happens after
+	// every frame derivable from data sent so far, so frames never shift
+	// into the next Decode call.
+	//
+	// Go's select picks randomly among ready cases, so idleCh and frameCh
+	// can both be ready at once; draining frameCh once more on the idleCh
+	// path (non-blocking) covers that without reordering anything, since the
+	// happens-before edge between the frame send and the idle signal (both
+	// from the same goroutine, in that order) guarantees any such frame is
+	// already sitting in frameCh's buffer by the time idleCh fires.
+	var out [][]float32
+	for {
+		select {
+		case frame, ok := <-d.frameCh:
+			if !ok {
+				return out, nil
+			}
+			out = mergeChannels(out, frame)
+		case err := <-d.doneCh:
+			return drainFrames(d.frameCh, out), err
+		case <-d.idleCh:
+			return drainFrames(d.frameCh, out), nil
+		}
+	}
+}
+
+// drainFrames appends every frame already buffered in frameCh onto out
+// without blocking, for use once a terminal signal (idle or done) has fired
+// and may have raced ahead of frames sent just before it.
+func drainFrames(frameCh <-chan [][]float32, out [][]float32) [][]float32 {
+	for {
+		select {
+		case frame, ok := <-frameCh:
+			if !ok {
+				return out
+			}
+			out = mergeChannels(out, frame)
+		default:
+			return out
+		}
+	}
+}
+
+// mergeChannels appends each channel of frame onto the matching channel of
+// out, growing out as needed.
+func mergeChannels(out [][]float32, frame [][]float32) [][]float32 {
+	if len(frame) > len(out) {
+		grown := make([][]float32, len(frame))
+		copy(grown, out)
+		out = grown
+	}
+	for c, samples := range frame {
+		out[c] = append(out[c], samples...)
+	}
+	return out
+}
@@ -0,0 +1,136 @@
+// decoder_test.go
+package myaudio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestUnsigned8Decode(t *testing.T) {
+	cases := []struct {
+		name string
+		in   byte
+		want float32
+	}{
+		{"silence", 128, 0},
+		{"full scale negative", 0, -1},
+		{"near full scale positive", 255, 127.0 / 128.0},
+	}
+
+	d := &unsigned8Decoder{sampleRate: 8000, channels: 1}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := d.Decode([]byte{tc.in})
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			if len(out) != 1 || len(out[0]) != 1 {
+				t.Fatalf("unexpected shape: %+v", out)
+			}
+			if got := out[0][0]; math.Abs(float64(got-tc.want)) > 1e-6 {
+				t.Errorf("byte %d: got %v, want %v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPCM8SignedDecode(t *testing.T) {
+	d := &pcmDecoder{bitDepth: 8, sampleRate: 8000, channels: 1}
+
+	out, err := d.Decode([]byte{0x00, 0x80, 0x7F})
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := []float32{0, -1, 127.0 / 128.0}
+	if len(out[0]) != len(want) {
+		t.Fatalf("got %d samples, want %d", len(out[0]), len(want))
+	}
+	for i, w := range want {
+		if math.Abs(float64(out[0][i]-w)) > 1e-6 {
+			t.Errorf("sample %d: got %v, want %v", i, out[0][i], w)
+		}
+	}
+}
+
+func TestPCM16LERoundTrip(t *testing.T) {
+	d := &pcmDecoder{bitDepth: 16, sampleRate: 44100, channels: 1}
+
+	// -32768, 0, 32767 little-endian
+	raw := []byte{0x00, 0x80, 0x00, 0x00, 0xFF, 0x7F}
+	out, err := d.Decode(raw)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want := []float32{-1, 0, 32767.0 / 32768.0}
+	for i, w := range want {
+		if math.Abs(float64(out[0][i]-w)) > 1e-6 {
+			t.Errorf("sample %d: got %v, want %v", i, out[0][i], w)
+		}
+	}
+}
+
+func TestPCMDecodeRejectsShortBuffer(t *testing.T) {
+	d := &pcmDecoder{bitDepth: 16, sampleRate: 44100, channels: 1}
+	if _, err := d.Decode([]byte{0x00}); err == nil {
+		t.Fatal("expected an error for a buffer shorter than one frame")
+	}
+}
+
+func TestNewDecoderUnknownFormat(t *testing.T) {
+	if _, err := NewDecoder("not-a-real-format", 44100, 1); err == nil {
+		t.Fatal("expected an error for an unregistered format")
+	}
+}
+
+// TestFlacDecoderDeliversFramesWithinSameCall exercises flacDecoder's
+// chunkReader/idleCh synchronization directly, standing in a trivial
+// one-byte-per-frame "parser" goroutine for the real flac.Stream so the test
+// isn't tied to the mewkiz/flac wire format. Reading one byte at a time
+// forces chunkReader to hand control back to the parser goroutine's loop
+// (and, once its buffer empties, back to Decode via idleCh) multiple times
+// per chunk, which is exactly the window a premature idle signal could have
+// raced ahead of a frame still waiting to be sent. It runs many times in a
+// single test (rather than relying on -count from the caller) because the
+// bug this guards against is a goroutine-scheduling race: any one run could
+// get lucky even with the old, broken synchronization.
+func TestFlacDecoderDeliversFramesWithinSameCall(t *testing.T) {
+	for i := 0; i < 200; i++ {
+		d := &flacDecoder{sampleRate: 8000, channels: 1}
+		d.chunks = make(chan []byte)
+		d.frameCh = make(chan [][]float32, 4)
+		d.doneCh = make(chan error, 1)
+		d.idleCh = make(chan struct{}, 1)
+		reader := &chunkReader{chunks: d.chunks, idleCh: d.idleCh}
+
+		go func() {
+			defer close(d.frameCh)
+			buf := make([]byte, 1)
+			for {
+				n, err := reader.Read(buf)
+				if n > 0 {
+					d.frameCh <- [][]float32{{float32(buf[0])}}
+				}
+				if err != nil {
+					d.doneCh <- nil
+					return
+				}
+			}
+		}()
+
+		out, err := d.Decode([]byte{1, 2, 3})
+		if err != nil {
+			t.Fatalf("run %d: Decode: %v", i, err)
+		}
+		if len(out) != 1 || len(out[0]) != 3 {
+			t.Fatalf("run %d: expected all 3 frames written in this call to come back from the same call, got %+v", i, out)
+		}
+		want := []float32{1, 2, 3}
+		for j, w := range want {
+			if out[0][j] != w {
+				t.Errorf("run %d: sample %d: got %v, want %v", i, j, out[0][j], w)
+			}
+		}
+
+		close(d.chunks)
+	}
+}
@@ -0,0 +1,70 @@
+// frames.go
+package myaudio
+
+import "sync"
+
+// Frame is one chunk of raw audio handed to ProcessData for a source. It is
+// broadcast to any subscriber tapping the live stream, e.g. the
+// /stream/:source endpoint in httpcontroller.
+type Frame struct {
+	Seq        uint64
+	PCM        []byte
+	SampleRate int
+	Channels   int
+}
+
+// frameHub fans out Frames to any number of subscribers, keyed by source ID,
+// and assigns each source its own monotonically increasing sequence number.
+type frameHub struct {
+	mu          sync.Mutex
+	seq         map[string]uint64
+	subscribers map[string]map[chan Frame]struct{}
+}
+
+// Frames is the process-wide live frame hub. ProcessData publishes to it so
+// browser UIs or downstream ML services can tap the raw audio without
+// polling files.
+var Frames = &frameHub{
+	seq:         map[string]uint64{},
+	subscribers: map[string]map[chan Frame]struct{}{},
+}
+
+// Publish broadcasts one frame of raw audio for source to all current
+// subscribers, assigning it the next sequence number for that source.
+func (h *frameHub) Publish(source string, pcm []byte, sampleRate, channels int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if len(h.subscribers[source]) == 0 {
+		return
+	}
+
+	h.seq[source]++
+	frame := Frame{Seq: h.seq[source], PCM: pcm, SampleRate: sampleRate, Channels: channels}
+	for ch := range h.subscribers[source] {
+		select {
+		case ch <- frame:
+		default:
+			// Slow subscriber; drop the frame rather than block capture.
+		}
+	}
+}
+
+// Subscribe registers a new listener for source's live frames.
+func (h *frameHub) Subscribe(source string) chan Frame {
+	ch := make(chan Frame, 32)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscribers[source] == nil {
+		h.subscribers[source] = map[chan Frame]struct{}{}
+	}
+	h.subscribers[source][ch] = struct{}{}
+	return ch
+}
+
+// Unsubscribe removes a previously registered listener.
+func (h *frameHub) Unsubscribe(source string, ch chan Frame) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subscribers[source], ch)
+	close(ch)
+}
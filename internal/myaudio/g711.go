@@ -0,0 +1,42 @@
+// g711.go
+package myaudio
+
+// alawToLinear expands an A-law encoded byte to a linear float32 sample in
+// the [-1.0, 1.0] range, per ITU-T G.711.
+func alawToLinear(a byte) float32 {
+	a ^= 0x55
+
+	sign := a & 0x80
+	exponent := (a >> 4) & 0x07
+	mantissa := a & 0x0F
+
+	var sample int32
+	if exponent == 0 {
+		sample = int32(mantissa)<<4 + 8
+	} else {
+		sample = (int32(mantissa)<<4 + 0x108) << (exponent - 1)
+	}
+	if sign == 0 {
+		sample = -sample
+	}
+
+	return float32(sample) / 32768.0
+}
+
+// ulawToLinear expands a mu-law encoded byte to a linear float32 sample in
+// the [-1.0, 1.0] range, per ITU-T G.711.
+func ulawToLinear(u byte) float32 {
+	u = ^u
+
+	sign := u & 0x80
+	exponent := (u >> 4) & 0x07
+	mantissa := u & 0x0F
+
+	sample := (int32(mantissa)<<3 + 0x84) << exponent
+	sample -= 0x84
+	if sign != 0 {
+		sample = -sample
+	}
+
+	return float32(sample) / 32768.0
+}
@@ -0,0 +1,36 @@
+// g711_test.go
+package myaudio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestALawToLinearSilence(t *testing.T) {
+	// 0xD5 is A-law's encoding of zero; the quantization step near zero
+	// keeps this from being exactly 0.
+	got := alawToLinear(0xD5)
+	if math.Abs(float64(got)) > 0.001 {
+		t.Errorf("alawToLinear(0xD5) = %v, want ~0", got)
+	}
+}
+
+func TestULawToLinearSilence(t *testing.T) {
+	// 0xFF is mu-law's encoding of zero.
+	got := ulawToLinear(0xFF)
+	if math.Abs(float64(got)) > 1e-6 {
+		t.Errorf("ulawToLinear(0xFF) = %v, want ~0", got)
+	}
+}
+
+func TestG711DecodeSignIsSymmetric(t *testing.T) {
+	// The sign bit (0x80) should produce values of opposite sign for both
+	// codecs, since a cleared sign bit means negative in both encodings.
+	for _, expand := range []func(byte) float32{alawToLinear, ulawToLinear} {
+		positive := expand(0xAA)
+		negative := expand(0x2A)
+		if (positive > 0) == (negative > 0) {
+			t.Errorf("expected opposite signs, got %v and %v", positive, negative)
+		}
+	}
+}
@@ -0,0 +1,262 @@
+// httpsource.go
+package myaudio
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/tphakala/birdnet-go/internal/birdnet"
+)
+
+// FrameTiming carries the wall-clock and monotonic position of one chunk of
+// audio read from an HTTPSource, so external tools can align detections
+// with the original stream position.
+type FrameTiming struct {
+	WallClock      time.Time // when this chunk was read
+	MonotonicNanos int64     // nanoseconds since the stream started
+	FrameCount     int       // chunk sequence number, starting at 0
+}
+
+// TimingSink receives a FrameTiming event for every chunk an HTTPSource
+// reads. httpcontroller wires one in to forward events over SSE.
+type TimingSink func(source string, t FrameTiming)
+
+// HTTPSourceConfig configures an HTTPSource.
+type HTTPSourceConfig struct {
+	ID         string // source ID, used for ProcessData's source label and metrics
+	URL        string // HTTP(S) URL of a stream or file in Format; no MP3, OGG or Opus decoder is registered
+	Format     string // decoder format name, see NewDecoder
+	SampleRate int
+	Channels   int
+	ChunkBytes int           // bytes read per ProcessData call
+	MaxBackoff time.Duration // ceiling for reconnect backoff; defaults to 30s
+}
+
+// HTTPSource pulls audio from an HTTP(S) URL. File URLs are read with
+// Range-based seeking so a dropped connection resumes where it left off;
+// Icecast-style endless streams are reconnected from the live edge with
+// exponential backoff.
+type HTTPSource struct {
+	cfg    HTTPSourceConfig
+	client *http.Client
+}
+
+// NewHTTPSource builds an HTTPSource, validating cfg.Format against the
+// decoder registry up front so a misconfigured format fails at setup rather
+// than mid-stream. It does not keep the Decoder it builds to check this:
+// Run constructs a fresh one for every connection attempt instead, since a
+// stateful decoder like flacDecoder assumes one continuous bitstream and
+// can't be safely reused across a reconnect's brand-new one.
+func NewHTTPSource(cfg HTTPSourceConfig) (*HTTPSource, error) {
+	if _, err := NewDecoder(cfg.Format, cfg.SampleRate, cfg.Channels); err != nil {
+		return nil, fmt.Errorf("myaudio: configuring HTTP source %q: %w", cfg.ID, err)
+	}
+	if cfg.ChunkBytes <= 0 {
+		cfg.ChunkBytes = 64 * 1024
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 30 * time.Second
+	}
+	return &HTTPSource{
+		cfg:    cfg,
+		client: &http.Client{},
+	}, nil
+}
+
+// backoffResetAfter is how long a connection must stream successfully
+// before a subsequent disconnect is treated as a fresh failure (backoff
+// restarting at 1s) rather than a continuation of a prior outage.
+const backoffResetAfter = 30 * time.Second
+
+// Run reads audio from cfg.URL until ctx is cancelled, feeding each chunk
+// through ProcessData on the same queue path live sources use. onTiming
+// may be nil; when set, it is called once per chunk with its timing info.
+//
+// Run registers this source with Sources for the duration of the call, so
+// the /api/v1/sources introspection API, the timing SSE endpoint, and
+// /stream/:source can all find it by cfg.ID; it unregisters on return.
+func (s *HTTPSource) Run(ctx context.Context, bn *birdnet.BirdNET, onTiming TimingSink) error {
+	Sources.Register(NewSource(s.cfg.ID, s.cfg.ID, "http", s.cfg.Format, s.cfg.SampleRate, s.cfg.Channels, 0))
+	defer Sources.Unregister(s.cfg.ID)
+
+	streamStart := time.Now()
+	var readOffset int64
+	var frameSeq int
+	backoff := time.Second
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		body, seekable, err := s.open(ctx, readOffset)
+		if err != nil {
+			if !s.sleepBackoff(ctx, &backoff) {
+				return ctx.Err()
+			}
+			continue
+		}
+		opened := time.Now()
+
+		// A fresh Decoder per connection attempt, not the one (if any) from
+		// the previous attempt: a stateful decoder like flacDecoder assumes
+		// its background goroutine is parsing one continuous bitstream, and
+		// this connection's bytes are a brand-new stream with its own
+		// header, not a continuation of the last one.
+		decoder, err := NewDecoder(s.cfg.Format, s.cfg.SampleRate, s.cfg.Channels)
+		if err != nil {
+			body.Close()
+			if !s.sleepBackoff(ctx, &backoff) {
+				return ctx.Err()
+			}
+			continue
+		}
+
+		n, err := s.stream(ctx, bn, body, decoder, streamStart, &readOffset, &frameSeq, onTiming)
+		body.Close()
+		closeDecoder(decoder)
+		readOffset += n
+
+		if time.Since(opened) >= backoffResetAfter {
+			// This connection was stable for a while; a disconnect now is a
+			// fresh blip, not a continuation of a prior sustained outage.
+			backoff = time.Second
+		}
+
+		if err == nil || errors.Is(err, context.Canceled) {
+			return err
+		}
+		if !seekable {
+			// A live stream can't be resumed from a byte offset; restart
+			// from the current live edge instead.
+			readOffset = 0
+		}
+		if !s.sleepBackoff(ctx, &backoff) {
+			return ctx.Err()
+		}
+	}
+}
+
+// open issues the GET request for the stream, using a Range header to
+// resume file URLs from offset. It reports whether the stream is seekable /
+// resumable: the server either honored the Range request (206), or answered
+// a request from offset 0 with 200 and "Accept-Ranges: bytes".
+func (s *HTTPSource) open(ctx context.Context, offset int64) (io.ReadCloser, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.cfg.URL, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, offset == 0 && resp.Header.Get("Accept-Ranges") == "bytes", nil
+	case http.StatusPartialContent:
+		return resp.Body, true, nil
+	default:
+		resp.Body.Close()
+		return nil, false, fmt.Errorf("myaudio: GET %s: unexpected status %s", s.cfg.URL, resp.Status)
+	}
+}
+
+// stream reads fixed-size chunks from body until it ends or errors,
+// decoding each one and handing it to ProcessData. It returns the number
+// of bytes read in this call, for the caller's running offset.
+func (s *HTTPSource) stream(ctx context.Context, bn *birdnet.BirdNET, body io.Reader, decoder Decoder, streamStart time.Time, readOffset *int64, frameSeq *int, onTiming TimingSink) (int64, error) {
+	buf := make([]byte, s.cfg.ChunkBytes)
+	var read int64
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return read, err
+		}
+
+		n, err := io.ReadFull(body, buf)
+		if n > 0 {
+			chunk := make([]byte, n)
+			copy(chunk, buf[:n])
+
+			now := time.Now()
+			if procErr := ProcessData(bn, chunk, now, s.cfg.ID, decoder); procErr != nil {
+				return read, fmt.Errorf("myaudio: processing HTTP source %q: %w", s.cfg.ID, procErr)
+			}
+			if onTiming != nil {
+				onTiming(s.cfg.ID, FrameTiming{
+					WallClock:      now,
+					MonotonicNanos: now.Sub(streamStart).Nanoseconds(),
+					FrameCount:     *frameSeq,
+				})
+			}
+			read += int64(n)
+			*frameSeq++
+		}
+
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return read, err
+			}
+			return read, err
+		}
+	}
+}
+
+// sleepBackoff waits for the current backoff duration (or until ctx is
+// done) and doubles it, up to cfg.MaxBackoff, for the next reconnect
+// attempt. It returns false if ctx was cancelled while waiting.
+func (s *HTTPSource) sleepBackoff(ctx context.Context, backoff *time.Duration) bool {
+	timer := time.NewTimer(*backoff)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		*backoff *= 2
+		if *backoff > s.cfg.MaxBackoff {
+			*backoff = s.cfg.MaxBackoff
+		}
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// StartHTTPSources builds and runs one HTTPSource per entry in cfgs, each in
+// its own goroutine, until ctx is cancelled. HTTP sources run only when a
+// caller invokes StartHTTPSources or HTTPSource.Run; the sound-card and RTSP
+// startup code does not call either. A source that fails to construct (bad Format)
+// is logged and skipped rather than aborting the others; a source whose Run
+// returns a non-cancellation error is also logged, since Run itself already
+// retries transient failures internally.
+//
+// Callers that want per-source completion tracking (e.g. to wait for a
+// graceful shutdown) should use the returned *sync.WaitGroup.
+func StartHTTPSources(ctx context.Context, cfgs []HTTPSourceConfig, bn *birdnet.BirdNET, onTiming TimingSink) *sync.WaitGroup {
+	var wg sync.WaitGroup
+	for _, cfg := range cfgs {
+		src, err := NewHTTPSource(cfg)
+		if err != nil {
+			log.Printf("myaudio: skipping HTTP source %q: %v", cfg.ID, err)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := src.Run(ctx, bn, onTiming); err != nil && !errors.Is(err, context.Canceled) {
+				log.Printf("myaudio: HTTP source %q stopped: %v", cfg.ID, err)
+			}
+		}()
+	}
+	return &wg
+}
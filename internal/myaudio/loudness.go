@@ -0,0 +1,231 @@
+// loudness.go
+package myaudio
+
+import (
+	"math"
+	"sync"
+)
+
+// Constants from ITU-R BS.1770 / EBU R128.
+const (
+	r128BlockMs          = 400  // gating block length
+	r128OverlapFraction  = 0.75 // 75% overlap between consecutive blocks
+	r128AbsoluteGateLUFS = -70  // absolute silence gate
+	r128RelativeGateLU   = -10  // relative gate, LU below the ungated mean
+
+	// DefaultTargetLUFS is the EBU R128 program loudness target.
+	DefaultTargetLUFS = -23.0
+
+	// maxLoudnessGainDB clamps any single gain adjustment a
+	// LoudnessNormalizer applies, so a near-silent block can't be boosted
+	// into clipping or excessive noise.
+	maxLoudnessGainDB = 12.0
+)
+
+// biquad is a direct-form-II-transposed second-order IIR section, used to
+// build the K-weighting pre-filter cascade from ITU-R BS.1770.
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	z1, z2             float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.z1
+	f.z1 = f.b1*x - f.a1*y + f.z2
+	f.z2 = f.b2*x - f.a2*y
+	return y
+}
+
+// newHighShelf builds a high-shelf biquad (RBJ audio cookbook form), used
+// for the K-weighting filter's +4 dB @ 1681 Hz stage.
+func newHighShelf(sampleRate, freq, gainDB, q float64) *biquad {
+	a := math.Pow(10, gainDB/40)
+	w0 := 2 * math.Pi * freq / sampleRate
+	cosw0, sinw0 := math.Cos(w0), math.Sin(w0)
+	alpha := sinw0 / (2 * q)
+	twoSqrtAAlpha := 2 * math.Sqrt(a) * alpha
+
+	b0 := a * ((a + 1) + (a-1)*cosw0 + twoSqrtAAlpha)
+	b1 := -2 * a * ((a - 1) + (a+1)*cosw0)
+	b2 := a * ((a + 1) + (a-1)*cosw0 - twoSqrtAAlpha)
+	a0 := (a + 1) - (a-1)*cosw0 + twoSqrtAAlpha
+	a1 := 2 * ((a - 1) - (a+1)*cosw0)
+	a2 := (a + 1) - (a-1)*cosw0 - twoSqrtAAlpha
+
+	return &biquad{b0: b0 / a0, b1: b1 / a0, b2: b2 / a0, a1: a1 / a0, a2: a2 / a0}
+}
+
+// newHighPass builds a high-pass biquad (RBJ audio cookbook form), used for
+// the K-weighting filter's 38 Hz stage.
+func newHighPass(sampleRate, freq, q float64) *biquad {
+	w0 := 2 * math.Pi * freq / sampleRate
+	cosw0, sinw0 := math.Cos(w0), math.Sin(w0)
+	alpha := sinw0 / (2 * q)
+
+	b0 := (1 + cosw0) / 2
+	b1 := -(1 + cosw0)
+	b2 := (1 + cosw0) / 2
+	a0 := 1 + alpha
+	a1 := -2 * cosw0
+	a2 := 1 - alpha
+
+	return &biquad{b0: b0 / a0, b1: b1 / a0, b2: b2 / a0, a1: a1 / a0, a2: a2 / a0}
+}
+
+// LoudnessNormalizer measures integrated loudness per call using the
+// ITU-R BS.1770 / EBU R128 algorithm and scales the signal toward a
+// configurable target LUFS (default -23). Gain adjustments are clamped and
+// smoothed across successive calls, so consecutive 3-second BirdNET
+// windows don't audibly pump.
+type LoudnessNormalizer struct {
+	targetLUFS float64
+	maxGainDB  float64
+	smoothing  float64 // 0..1; higher reacts faster to the newly measured loudness
+	sampleRate int
+
+	mu            sync.Mutex
+	shelf, hp     []*biquad // one K-weighting cascade per channel
+	currentGainDB float64
+	warm          bool
+}
+
+// NewLoudnessNormalizer builds a Normalizer that measures integrated
+// loudness per call and scales toward targetLUFS, clamping any single
+// adjustment to +/-maxGainDB.
+func NewLoudnessNormalizer(sampleRate, channels int, targetLUFS, maxGainDB float64) *LoudnessNormalizer {
+	shelf := make([]*biquad, channels)
+	hp := make([]*biquad, channels)
+	for c := 0; c < channels; c++ {
+		shelf[c] = newHighShelf(float64(sampleRate), 1681, 4, 1/math.Sqrt2)
+		hp[c] = newHighPass(float64(sampleRate), 38, 0.5)
+	}
+	return &LoudnessNormalizer{
+		targetLUFS: targetLUFS,
+		maxGainDB:  maxGainDB,
+		smoothing:  0.5,
+		sampleRate: sampleRate,
+		shelf:      shelf,
+		hp:         hp,
+	}
+}
+
+// Apply implements Normalizer.
+func (n *LoudnessNormalizer) Apply(samples [][]float32) [][]float32 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	measured := n.integratedLoudness(samples)
+	targetGainDB := n.currentGainDB
+	if !math.IsInf(measured, -1) {
+		targetGainDB = clamp(n.targetLUFS-measured, -n.maxGainDB, n.maxGainDB)
+	}
+
+	if !n.warm {
+		// Snap to the first measurement instead of smoothing from zero gain.
+		n.currentGainDB = targetGainDB
+		n.warm = true
+	} else {
+		n.currentGainDB += (targetGainDB - n.currentGainDB) * n.smoothing
+	}
+
+	return scaleSamples(samples, dbToLinear32(n.currentGainDB))
+}
+
+// integratedLoudness computes the BS.1770 gated integrated loudness, in
+// LUFS, of samples across 400 ms blocks with 75% overlap.
+func (n *LoudnessNormalizer) integratedLoudness(samples [][]float32) float64 {
+	if len(samples) == 0 || len(samples[0]) == 0 {
+		return math.Inf(-1)
+	}
+	frames := len(samples[0])
+
+	// Run the K-weighting cascade once over the whole chunk, per channel,
+	// so filter state stays continuous across overlapping blocks.
+	filtered := make([][]float64, len(samples))
+	for c, channel := range samples {
+		if c >= len(n.shelf) {
+			break
+		}
+		buf := make([]float64, len(channel))
+		for i, v := range channel {
+			buf[i] = n.hp[c].process(n.shelf[c].process(float64(v)))
+		}
+		filtered[c] = buf
+	}
+
+	blockSize := int(float64(n.sampleRate) * r128BlockMs / 1000)
+	if blockSize <= 0 || blockSize > frames {
+		blockSize = frames
+	}
+	hop := int(float64(blockSize) * (1 - r128OverlapFraction))
+	if hop < 1 {
+		hop = 1
+	}
+
+	var blockLoudness []float64
+	for start := 0; start+blockSize <= frames; start += hop {
+		var sum float64
+		for _, buf := range filtered {
+			if buf == nil {
+				continue
+			}
+			var channelSum float64
+			for i := start; i < start+blockSize; i++ {
+				channelSum += buf[i] * buf[i]
+			}
+			sum += channelSum / float64(blockSize)
+		}
+		if sum <= 0 {
+			continue
+		}
+		blockLoudness = append(blockLoudness, -0.691+10*math.Log10(sum))
+	}
+	if len(blockLoudness) == 0 {
+		return math.Inf(-1)
+	}
+
+	// Absolute gate.
+	var absoluteGated []float64
+	for _, l := range blockLoudness {
+		if l > r128AbsoluteGateLUFS {
+			absoluteGated = append(absoluteGated, l)
+		}
+	}
+	if len(absoluteGated) == 0 {
+		return math.Inf(-1)
+	}
+
+	// Relative gate, 10 LU below the ungated mean of the absolute-gated blocks.
+	relativeThreshold := meanPowerLUFS(absoluteGated) + r128RelativeGateLU
+	var gated []float64
+	for _, l := range absoluteGated {
+		if l > relativeThreshold {
+			gated = append(gated, l)
+		}
+	}
+	if len(gated) == 0 {
+		gated = absoluteGated
+	}
+
+	return meanPowerLUFS(gated)
+}
+
+// meanPowerLUFS averages LUFS values in the power domain, as BS.1770 requires.
+func meanPowerLUFS(loudnessLUFS []float64) float64 {
+	var sum float64
+	for _, l := range loudnessLUFS {
+		sum += math.Pow(10, (l+0.691)/10)
+	}
+	mean := sum / float64(len(loudnessLUFS))
+	return -0.691 + 10*math.Log10(mean)
+}
+
+func clamp(v, lo, hi float64) float64 {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
@@ -0,0 +1,46 @@
+// loudness_test.go
+package myaudio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLoudnessNormalizerBoostsQuietSignalAndClamps(t *testing.T) {
+	const sampleRate = 48000
+	n := NewLoudnessNormalizer(sampleRate, 1, DefaultTargetLUFS, maxLoudnessGainDB)
+
+	// A full second of a very quiet tone is well below DefaultTargetLUFS, so
+	// the normalizer should ask for more gain than maxLoudnessGainDB allows
+	// and get clamped rather than pumping the signal toward clipping.
+	samples := make([]float32, sampleRate)
+	for i := range samples {
+		samples[i] = 0.01 * float32(math.Sin(2*math.Pi*440*float64(i)/sampleRate))
+	}
+
+	out := n.Apply([][]float32{samples})
+
+	if n.currentGainDB != maxLoudnessGainDB {
+		t.Fatalf("currentGainDB = %v, want it clamped at +%g dB", n.currentGainDB, maxLoudnessGainDB)
+	}
+
+	want := dbToLinear32(maxLoudnessGainDB)
+	const idx = 100 // away from the sine's zero crossings
+	if got := out[0][idx] / samples[idx]; math.Abs(float64(got-want)) > 1e-4 {
+		t.Fatalf("sample %d: gain %v, want %v", idx, got, want)
+	}
+}
+
+func TestLoudnessNormalizerSilenceLeavesGainUnchanged(t *testing.T) {
+	const sampleRate = 48000
+	n := NewLoudnessNormalizer(sampleRate, 1, DefaultTargetLUFS, maxLoudnessGainDB)
+	n.currentGainDB = 3
+	n.warm = true
+
+	silence := make([]float32, sampleRate)
+	n.Apply([][]float32{silence})
+
+	if n.currentGainDB != 3 {
+		t.Fatalf("currentGainDB = %v, want unchanged at 3 (absolute gate rejects silence)", n.currentGainDB)
+	}
+}
@@ -0,0 +1,71 @@
+// normalizer.go
+package myaudio
+
+import "math"
+
+// Normalizer adjusts decoded sample levels before they reach BirdNET, so
+// quiet or heavily compressed sources don't starve inference of confidence.
+// A Source's Normalizer is invoked in ProcessData between the Decoder and
+// bn.Predict.
+type Normalizer interface {
+	// Apply returns level-adjusted samples. Implementations may be
+	// stateful across calls, e.g. to smooth gain changes across
+	// successive BirdNET windows.
+	Apply(samples [][]float32) [][]float32
+}
+
+// maxFixedGainDB clamps the gain FixedGainNormalizer and ReplayGainNormalizer
+// will apply, mirroring loudness.go's maxLoudnessGainDB: an API client or a
+// bogus ReplayGain tag requesting an extreme gain would otherwise pump
+// samples toward +/-Inf/NaN before they reach BirdNET.
+const maxFixedGainDB = 24.0
+
+// FixedGainNormalizer applies a constant, user-specified gain in dB.
+type FixedGainNormalizer struct {
+	multiplier float32
+}
+
+// NewFixedGainNormalizer builds a Normalizer that scales every sample by the
+// linear equivalent of gainDB, clamped to +/-maxFixedGainDB.
+func NewFixedGainNormalizer(gainDB float64) *FixedGainNormalizer {
+	return &FixedGainNormalizer{multiplier: dbToLinear32(clamp(gainDB, -maxFixedGainDB, maxFixedGainDB))}
+}
+
+// Apply implements Normalizer.
+func (n *FixedGainNormalizer) Apply(samples [][]float32) [][]float32 {
+	return scaleSamples(samples, n.multiplier)
+}
+
+// ReplayGainNormalizer applies a fixed pre-gain read once from a file's
+// ReplayGain or R128_TRACK_GAIN tag, for file-based analysis where the
+// whole track's gain is known up front.
+type ReplayGainNormalizer struct {
+	multiplier float32
+}
+
+// NewReplayGainNormalizer builds a Normalizer from a ReplayGain-style track
+// gain value in dB, as found in file tags, clamped to +/-maxFixedGainDB.
+func NewReplayGainNormalizer(trackGainDB float64) *ReplayGainNormalizer {
+	return &ReplayGainNormalizer{multiplier: dbToLinear32(clamp(trackGainDB, -maxFixedGainDB, maxFixedGainDB))}
+}
+
+// Apply implements Normalizer.
+func (n *ReplayGainNormalizer) Apply(samples [][]float32) [][]float32 {
+	return scaleSamples(samples, n.multiplier)
+}
+
+func dbToLinear32(db float64) float32 {
+	return float32(math.Pow(10, db/20))
+}
+
+func scaleSamples(samples [][]float32, multiplier float32) [][]float32 {
+	out := make([][]float32, len(samples))
+	for c, channel := range samples {
+		scaled := make([]float32, len(channel))
+		for i, v := range channel {
+			scaled[i] = v * multiplier
+		}
+		out[c] = scaled
+	}
+	return out
+}
@@ -0,0 +1,28 @@
+// normalizer_test.go
+package myaudio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFixedGainNormalizerClampsExtremeGain(t *testing.T) {
+	n := NewFixedGainNormalizer(1000)
+	want := dbToLinear32(maxFixedGainDB)
+	if math.Abs(float64(n.multiplier-want)) > 1e-6 {
+		t.Fatalf("multiplier = %v, want %v (clamped to +%g dB)", n.multiplier, want, maxFixedGainDB)
+	}
+
+	out := n.Apply([][]float32{{1}})
+	if math.IsInf(float64(out[0][0]), 0) || math.IsNaN(float64(out[0][0])) {
+		t.Fatalf("Apply produced non-finite output: %v", out[0][0])
+	}
+}
+
+func TestReplayGainNormalizerClampsExtremeGain(t *testing.T) {
+	n := NewReplayGainNormalizer(-1000)
+	want := dbToLinear32(-maxFixedGainDB)
+	if math.Abs(float64(n.multiplier-want)) > 1e-6 {
+		t.Fatalf("multiplier = %v, want %v (clamped to -%g dB)", n.multiplier, want, maxFixedGainDB)
+	}
+}
@@ -2,26 +2,56 @@
 package myaudio
 
 import (
-	"errors"
 	"fmt"
-	"log"
 	"time"
 
 	"github.com/tphakala/birdnet-go/internal/analysis/queue"
 	"github.com/tphakala/birdnet-go/internal/birdnet"
-	"github.com/tphakala/birdnet-go/internal/conf"
 )
 
 // processData processes the given audio data to detect bird species, logs the detected species
 // and optionally saves the audio clip if a bird species is detected above the configured threshold.
-func ProcessData(bn *birdnet.BirdNET, data []byte, startTime time.Time, source string) error {
+// decoder must be the Decoder configured for the source data was read from, so that heterogeneous
+// sources (USB card, RTSP, HTTP stream) each using their own codec can be processed concurrently.
+func ProcessData(bn *birdnet.BirdNET, data []byte, startTime time.Time, source string, decoder Decoder) error {
 	// get current time to track processing time
 	predictStart := time.Now()
 
-	// convert audio data to float32
-	sampleData, err := ConvertToFloat32(data, conf.BitDepth)
+	// look up the named source once, used below to pick the active decoder,
+	// apply loudness normalization, filter results, and record per-source
+	// metrics
+	src, hasSource := Sources.Get(source)
+
+	// a hot-reconfigured bit depth override (see Source.Reconfigure) changes
+	// how this chunk must be decoded, so it has to be resolved before Decode
+	// runs rather than applied to the already-decoded samples
+	activeDecoder := decoder
+	if hasSource {
+		if bitDepth, ok := src.BitDepthOverride(); ok {
+			overridden, err := decoderWithBitDepth(decoder, bitDepth)
+			if err != nil {
+				return fmt.Errorf("error applying bit depth override for source %q: %w", source, err)
+			}
+			activeDecoder = overridden
+		}
+	}
+
+	// convert audio data to float32 using the source's configured decoder
+	sampleData, err := activeDecoder.Decode(data)
 	if err != nil {
-		return fmt.Errorf("error converting %v bit PCM data to float32: %w", conf.BitDepth, err)
+		return fmt.Errorf("error decoding audio data from source %q: %w", source, err)
+	}
+
+	// broadcast the raw frame to any live /stream/:source subscribers,
+	// tapping the same frames ProcessData consumes
+	Frames.Publish(source, data, activeDecoder.SampleRate(), activeDecoder.Channels())
+
+	// apply the source's configured loudness/gain normalizer, if any, so
+	// quiet or heavily compressed streams don't starve BirdNET of confidence
+	if hasSource {
+		if norm := src.Normalizer(); norm != nil {
+			sampleData = norm.Apply(sampleData)
+		}
 	}
 
 	// run BirdNET inference
@@ -30,6 +60,25 @@ func ProcessData(bn *birdnet.BirdNET, data []byte, startTime time.Time, source s
 		return fmt.Errorf("error predicting species: %w", err)
 	}
 
+	// apply the source's confidence threshold and species filter, if either
+	// is configured, before anything downstream sees the results
+	if hasSource {
+		confidenceThreshold, speciesFilter := src.FilterConfig()
+		if confidenceThreshold > 0 || len(speciesFilter) > 0 {
+			filtered := results[:0:0]
+			for _, r := range results {
+				if confidenceThreshold > 0 && float64(r.Confidence) < confidenceThreshold {
+					continue
+				}
+				if len(speciesFilter) > 0 && !containsString(speciesFilter, r.Species) {
+					continue
+				}
+				filtered = append(filtered, r)
+			}
+			results = filtered
+		}
+	}
+
 	// DEBUG print species of all results
 	/*for i := 0; i < len(results); i++ {
 		if results[i].Confidence > 0.01 {
@@ -40,6 +89,15 @@ func ProcessData(bn *birdnet.BirdNET, data []byte, startTime time.Time, source s
 	// get elapsed time and log if enabled
 	elapsedTime := logProcessingTime(predictStart)
 
+	// attribute this chunk to its named source, if one is registered, so
+	// the /api/v1/sources introspection endpoint reports live metrics
+	if hasSource {
+		src.RecordFrame(len(data), elapsedTime)
+		if len(results) > 0 {
+			src.RecordDetection(startTime)
+		}
+	}
+
 	// Create a Results message to be sent through queue to processor
 	resultsMessage := queue.Results{
 		StartTime:   startTime,   // Timestamp when the audio data was received
@@ -49,14 +107,9 @@ func ProcessData(bn *birdnet.BirdNET, data []byte, startTime time.Time, source s
 		Source:      source,      // Source of the audio data, RSTP URL or audio card name
 	}
 
-	// Send the results to the queue
-	select {
-	case queue.ResultsQueue <- &resultsMessage:
-		// Results enqueued successfully
-	default:
-		log.Println("Queue is full!")
-		// Queue is full
-	}
+	// Send the results to the queue, applying the configured backpressure
+	// policy (block-with-timeout, drop-oldest, or drop-newest) if full
+	enqueueResult(&resultsMessage)
 	return nil
 }
 
@@ -69,62 +122,72 @@ func logProcessingTime(startTime time.Time) time.Duration {
 	return elapsedTime
 }
 
-// ConvertToFloat32 converts a byte slice representing sample to a 2D slice of float32 samples.
-// The function supports 16, 24, and 32 bit depths.
-func ConvertToFloat32(sample []byte, bitDepth int) ([][]float32, error) {
+// decoderWithBitDepth builds a decoder identical to base (same byte order,
+// sample rate and channel count) except decoding signed integer samples at
+// bitDepth instead of base's configured depth, for Source.Reconfigure's
+// BitDepthOverride; a float32 base is decoded as integer PCM. base must be
+// a *pcmDecoder; other decoder types (FLAC, G.711, unsigned 8-bit) have no
+// meaningful bit-depth override and are returned unchanged.
+func decoderWithBitDepth(base Decoder, bitDepth int) (Decoder, error) {
+	pcm, ok := base.(*pcmDecoder)
+	if !ok {
+		return base, nil
+	}
+	if _, err := legacyPCMFormat(bitDepth); err != nil {
+		return nil, err
+	}
+	return &pcmDecoder{bitDepth: bitDepth, bigEndian: pcm.bigEndian, sampleRate: pcm.sampleRate, channels: pcm.channels}, nil
+}
+
+// containsString reports whether needle is present in haystack.
+func containsString(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
+
+// legacyPCMFormat maps the old conf.BitDepth setting to the equivalent
+// little-endian PCM decoder format, for callers that haven't migrated to
+// per-source decoder configuration yet.
+func legacyPCMFormat(bitDepth int) (string, error) {
 	switch bitDepth {
 	case 16:
-		return [][]float32{convert16BitToFloat32(sample)}, nil
+		return FormatPCM16LE, nil
 	case 24:
-		return [][]float32{convert24BitToFloat32(sample)}, nil
+		return FormatPCM24LE, nil
 	case 32:
-		return [][]float32{convert32BitToFloat32(sample)}, nil
+		return FormatPCM32LE, nil
 	default:
-		return nil, errors.New("unsupported audio bit depth")
+		return "", fmt.Errorf("unsupported audio bit depth: %d", bitDepth)
 	}
 }
 
-// convert16BitToFloat32 converts 16-bit sample to float32 values.
-func convert16BitToFloat32(sample []byte) []float32 {
-	length := len(sample) / 2
-	float32Data := make([]float32, length)
-	divisor := float32(32768.0)
-
-	for i := 0; i < length; i++ {
-		sample := int16(sample[i*2]) | int16(sample[i*2+1])<<8
-		float32Data[i] = float32(sample) / divisor
-	}
-
-	return float32Data
+// IsSupportedBitDepth reports whether bitDepth is one legacyPCMFormat (and
+// therefore decoderWithBitDepth/Source.Reconfigure's BitDepthOverride)
+// accepts. Callers that take a bit depth from outside the process, such as
+// the PUT /api/v1/sources/:id handler, should check this before passing the
+// value through, since BitDepthOverride otherwise fails silently at decode
+// time on every subsequent chunk instead of at the point it was set.
+func IsSupportedBitDepth(bitDepth int) bool {
+	_, err := legacyPCMFormat(bitDepth)
+	return err == nil
 }
 
-// convert24BitToFloat32 converts 24-bit sample to float32 values.
-func convert24BitToFloat32(sample []byte) []float32 {
-	length := len(sample) / 3
-	float32Data := make([]float32, length)
-	divisor := float32(8388608.0)
-
-	for i := 0; i < length; i++ {
-		sample := int32(sample[i*3]) | int32(sample[i*3+1])<<8 | int32(sample[i*3+2])<<16
-		if (sample & 0x00800000) > 0 {
-			sample |= ^0x00FFFFFF // Two's complement sign extension
-		}
-		float32Data[i] = float32(sample) / divisor
+// ConvertToFloat32 converts a byte slice representing sample to a 2D slice of float32 samples,
+// using conf.BitDepth's configured little-endian PCM decoder. It is kept for callers that
+// haven't moved to the per-source Decoder API yet; new code should look up a Decoder via
+// NewDecoder and call its Decode method directly.
+func ConvertToFloat32(sample []byte, bitDepth int) ([][]float32, error) {
+	format, err := legacyPCMFormat(bitDepth)
+	if err != nil {
+		return nil, err
 	}
-
-	return float32Data
-}
-
-// convert32BitToFloat32 converts 32-bit sample to float32 values.
-func convert32BitToFloat32(sample []byte) []float32 {
-	length := len(sample) / 4
-	float32Data := make([]float32, length)
-	divisor := float32(2147483648.0)
-
-	for i := 0; i < length; i++ {
-		sample := int32(sample[i*4]) | int32(sample[i*4+1])<<8 | int32(sample[i*4+2])<<16 | int32(sample[i*4+3])<<24
-		float32Data[i] = float32(sample) / divisor
+	decoder, err := NewDecoder(format, 0, 1)
+	if err != nil {
+		return nil, err
 	}
-
-	return float32Data
+	return decoder.Decode(sample)
 }
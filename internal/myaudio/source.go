@@ -0,0 +1,291 @@
+// source.go
+package myaudio
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// latencyBucketsMs are the upper bounds, in milliseconds, of the processing
+// latency histogram exposed per source. The final bucket is +Inf.
+var latencyBucketsMs = []float64{10, 25, 50, 100, 250, 500, 1000, 2500}
+
+// latencyHistogram is a small fixed-bucket histogram, local to a single
+// Source, tracking how long ProcessData takes per chunk.
+type latencyHistogram struct {
+	mu      sync.Mutex
+	buckets []uint64 // len(latencyBucketsMs)+1, last bucket is the overflow ("+Inf") bucket
+	count   uint64
+	sum     time.Duration
+}
+
+func (h *latencyHistogram) observe(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.buckets == nil {
+		h.buckets = make([]uint64, len(latencyBucketsMs)+1)
+	}
+
+	ms := float64(d) / float64(time.Millisecond)
+	idx := len(latencyBucketsMs)
+	for i, bound := range latencyBucketsMs {
+		if ms <= bound {
+			idx = i
+			break
+		}
+	}
+	h.buckets[idx]++
+	h.count++
+	h.sum += d
+}
+
+// snapshot returns the cumulative bucket counts keyed by their upper bound
+// in milliseconds, using "+Inf" for the overflow bucket.
+func (h *latencyHistogram) snapshot() map[string]uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.buckets == nil {
+		h.buckets = make([]uint64, len(latencyBucketsMs)+1)
+	}
+
+	out := make(map[string]uint64, len(latencyBucketsMs)+1)
+	var cumulative uint64
+	for i, bound := range latencyBucketsMs {
+		cumulative += h.buckets[i]
+		out[fmt.Sprintf("%g", bound)] = cumulative
+	}
+	cumulative += h.buckets[len(latencyBucketsMs)]
+	out["+Inf"] = cumulative
+	return out
+}
+
+// SourceConfig holds the hot-reconfigurable parameters of a Source. Nil
+// fields are left unchanged by Reconfigure.
+type SourceConfig struct {
+	Gain                *float64
+	ReplayGainDB        *float64 // pre-gain from a ReplayGain/R128_TRACK_GAIN tag, in dB
+	TargetLUFS          *float64 // EBU R128 integrated loudness target, in LUFS
+	BitDepthOverride    *int
+	ConfidenceThreshold *float64
+	SpeciesFilter       []string
+}
+
+// SourceSnapshot is a point-in-time, read-only copy of a Source's live
+// state, safe to serialize or hand to a caller without holding any locks.
+type SourceSnapshot struct {
+	Format              string
+	SampleRate          int
+	Channels            int
+	BitDepth            int
+	BytesProcessed      uint64
+	ListenerCount       int
+	LastDetection       time.Time
+	LatencyHistogramMs  map[string]uint64
+	Gain                float64
+	ConfidenceThreshold float64
+	SpeciesFilter       []string
+}
+
+// Source describes one running audio input — a sound card, an RTSP URL, an
+// HTTP stream, or a file — that ProcessData attributes frames to, so that
+// heterogeneous sources can each carry their own codec and live metrics.
+type Source struct {
+	ID   string
+	Name string
+	Kind string // "soundcard", "rtsp", "http", "file"
+
+	mu                  sync.RWMutex
+	format              string
+	sampleRate          int
+	channels            int
+	bitDepth            int  // nominal bit depth the source was configured with
+	bitDepthOverride    *int // set via Reconfigure; nil means "use bitDepth"
+	bytesProcessed      uint64
+	listenerCount       int
+	lastDetection       time.Time
+	gain                float64
+	confidenceThreshold float64
+	speciesFilter       []string
+	normalizer          Normalizer
+
+	latency latencyHistogram
+}
+
+// NewSource creates a Source with the given identity and initial decoder
+// parameters, ready to be registered with a SourceRegistry.
+func NewSource(id, name, kind, format string, sampleRate, channels, bitDepth int) *Source {
+	return &Source{
+		ID:         id,
+		Name:       name,
+		Kind:       kind,
+		format:     format,
+		sampleRate: sampleRate,
+		channels:   channels,
+		bitDepth:   bitDepth,
+	}
+}
+
+// RecordFrame updates the byte counter and processing latency histogram for
+// a single ProcessData call against this source.
+func (s *Source) RecordFrame(byteCount int, elapsed time.Duration) {
+	s.mu.Lock()
+	s.bytesProcessed += uint64(byteCount)
+	s.mu.Unlock()
+	s.latency.observe(elapsed)
+}
+
+// RecordDetection marks that BirdNET returned at least one result for a
+// frame from this source.
+func (s *Source) RecordDetection(at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastDetection = at
+}
+
+// AddListener adjusts the count of active consumers (e.g. live stream
+// clients) attached to this source.
+func (s *Source) AddListener(delta int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listenerCount += delta
+}
+
+// SetNormalizer installs the loudness/gain normalizer applied to this
+// source's samples before they reach BirdNET. A nil normalizer disables
+// normalization.
+func (s *Source) SetNormalizer(n Normalizer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.normalizer = n
+}
+
+// Normalizer returns the currently configured normalizer, or nil if none
+// has been set.
+func (s *Source) Normalizer() Normalizer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.normalizer
+}
+
+// Reconfigure applies a partial set of hot-reconfigurable parameters
+// without restarting the source's capture goroutine. Gain, ReplayGainDB,
+// and TargetLUFS each select and install a different Normalizer; if more
+// than one is set in the same call, the last one applied wins (Gain, then
+// ReplayGainDB, then TargetLUFS).
+func (s *Source) Reconfigure(cfg SourceConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cfg.Gain != nil {
+		s.gain = *cfg.Gain
+		s.normalizer = NewFixedGainNormalizer(*cfg.Gain)
+	}
+	if cfg.ReplayGainDB != nil {
+		s.normalizer = NewReplayGainNormalizer(*cfg.ReplayGainDB)
+	}
+	if cfg.TargetLUFS != nil {
+		s.normalizer = NewLoudnessNormalizer(s.sampleRate, s.channels, *cfg.TargetLUFS, maxLoudnessGainDB)
+	}
+	if cfg.BitDepthOverride != nil {
+		override := *cfg.BitDepthOverride
+		s.bitDepthOverride = &override
+	}
+	if cfg.ConfidenceThreshold != nil {
+		s.confidenceThreshold = *cfg.ConfidenceThreshold
+	}
+	if cfg.SpeciesFilter != nil {
+		s.speciesFilter = cfg.SpeciesFilter
+	}
+}
+
+// BitDepthOverride returns the bit depth ProcessData should decode this
+// source at, if one has been set via Reconfigure, and whether one is set.
+func (s *Source) BitDepthOverride() (int, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.bitDepthOverride == nil {
+		return 0, false
+	}
+	return *s.bitDepthOverride, true
+}
+
+// FilterConfig returns the confidence threshold and species filter
+// ProcessData should apply to this source's detections. A zero threshold
+// or empty filter means "no filtering" on that dimension.
+func (s *Source) FilterConfig() (confidenceThreshold float64, speciesFilter []string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.confidenceThreshold, s.speciesFilter
+}
+
+// Snapshot returns a consistent, lock-free copy of the source's current
+// state for reporting over the API.
+func (s *Source) Snapshot() SourceSnapshot {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	bitDepth := s.bitDepth
+	if s.bitDepthOverride != nil {
+		bitDepth = *s.bitDepthOverride
+	}
+	return SourceSnapshot{
+		Format:              s.format,
+		SampleRate:          s.sampleRate,
+		Channels:            s.channels,
+		BitDepth:            bitDepth,
+		BytesProcessed:      s.bytesProcessed,
+		ListenerCount:       s.listenerCount,
+		LastDetection:       s.lastDetection,
+		LatencyHistogramMs:  s.latency.snapshot(),
+		Gain:                s.gain,
+		ConfidenceThreshold: s.confidenceThreshold,
+		SpeciesFilter:       s.speciesFilter,
+	}
+}
+
+// sourceRegistry is a concurrency-safe directory of every running audio
+// input, indexed by ID, so ProcessData can attribute a chunk to its source
+// and the HTTP API can list and reconfigure sources by name.
+type sourceRegistry struct {
+	mu      sync.RWMutex
+	sources map[string]*Source
+}
+
+// Sources is the process-wide source registry. It is a package-level
+// singleton because exactly one registry exists per running instance,
+// mirroring the ResultsQueue pattern in the queue package.
+var Sources = &sourceRegistry{sources: map[string]*Source{}}
+
+// Register adds or replaces a source under its ID.
+func (r *sourceRegistry) Register(src *Source) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sources[src.ID] = src
+}
+
+// Unregister removes a source, e.g. when its capture goroutine exits.
+func (r *sourceRegistry) Unregister(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sources, id)
+}
+
+// Get looks up a source by ID.
+func (r *sourceRegistry) Get(id string) (*Source, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	src, ok := r.sources[id]
+	return src, ok
+}
+
+// All returns every registered source, in no particular order.
+func (r *sourceRegistry) All() []*Source {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	all := make([]*Source, 0, len(r.sources))
+	for _, src := range r.sources {
+		all = append(all, src)
+	}
+	return all
+}